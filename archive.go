@@ -0,0 +1,31 @@
+package main
+
+import "errors"
+
+// ErrUnsafeArchive is returned when an archive entry or structure fails
+// validation (nested too deep, too many entries, a suspicious compression
+// ratio, ...), so scanning is aborted instead of risking a decompression
+// bomb or runaway recursion.
+var ErrUnsafeArchive = errors.New("unsafe archive entry")
+
+// ArchiveLimits bounds how much an archive is allowed to expand to, as a
+// defense against zip bombs and other decompression-based DoS payloads.
+type ArchiveLimits struct {
+	MaxEntries               int     // max number of entries in a single archive
+	MaxEntryUncompressedSize int64   // max decompressed size of a single entry
+	MaxTotalUncompressedSize int64   // max decompressed size of one archive's entries
+	MaxCompressionRatio      float64 // max uncompressed:compressed ratio before bailing
+	MaxDepth                 int     // max nesting depth for archives-within-archives
+}
+
+// DefaultArchiveLimits returns conservative limits suitable for scanning
+// arbitrary, untrusted archives found on disk.
+func DefaultArchiveLimits() ArchiveLimits {
+	return ArchiveLimits{
+		MaxEntries:               10000,
+		MaxEntryUncompressedSize: 1 << 30, // 1 GiB
+		MaxTotalUncompressedSize: 4 << 30, // 4 GiB
+		MaxCompressionRatio:      100,
+		MaxDepth:                 5,
+	}
+}
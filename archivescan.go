@@ -0,0 +1,294 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blakesmith/ar"
+)
+
+// archiveKind identifies a container format recognized by its magic bytes.
+type archiveKind int
+
+const (
+	kindNone archiveKind = iota
+	kindZip
+	kindGzip
+	kindTar
+	kindAr
+)
+
+func (k archiveKind) String() string {
+	switch k {
+	case kindZip:
+		return "ZIP"
+	case kindGzip:
+		return "gzip"
+	case kindTar:
+		return "tar"
+	case kindAr:
+		return "ar/deb"
+	default:
+		return "archive"
+	}
+}
+
+var (
+	zipMagic    = []byte("PK\x03\x04")
+	zipEmpty    = []byte("PK\x05\x06")
+	gzipMagic   = []byte{0x1f, 0x8b}
+	arMagic     = []byte("!<arch>\n")
+	tarUSTAROff = 257
+)
+
+// detectArchiveKind classifies a container by its leading bytes (and, for
+// tar, the "ustar" marker at its fixed header offset) rather than trusting
+// the file's extension, so a renamed archive is still unpacked.
+func detectArchiveKind(header []byte) archiveKind {
+	switch {
+	case bytes.HasPrefix(header, zipMagic), bytes.HasPrefix(header, zipEmpty):
+		return kindZip
+	case bytes.HasPrefix(header, gzipMagic):
+		return kindGzip
+	case bytes.HasPrefix(header, arMagic):
+		return kindAr
+	case len(header) >= tarUSTAROff+5 && string(header[tarUSTAROff:tarUSTAROff+5]) == "ustar":
+		return kindTar
+	default:
+		return kindNone
+	}
+}
+
+// detectArchiveKindFromFile reads just enough of path's header to classify it.
+func detectArchiveKindFromFile(path string) (archiveKind, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return kindNone, err
+	}
+	defer f.Close()
+
+	header := make([]byte, tarUSTAROff+5)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return kindNone, err
+	}
+	return detectArchiveKind(header[:n]), nil
+}
+
+// scanArchive opens path and streams its contents through cfg's hashers with
+// no intermediate extraction to disk, recursing into nested archives up to
+// ArchiveLimits.MaxDepth. It returns every ScanResult collected before an
+// error (if any) aborted the rest of the archive.
+func scanArchive(path string, kind archiveKind, cfg ScanConfig, depth int) ([]ScanResult, error) {
+	limits := DefaultArchiveLimits()
+	if depth > limits.MaxDepth {
+		return nil, fmt.Errorf("%w: %s: nested archive depth exceeds limit of %d", ErrUnsafeArchive, path, limits.MaxDepth)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch kind {
+	case kindZip:
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		return scanZip(f, info.Size(), path, cfg, depth, limits)
+	case kindGzip:
+		return scanGzip(f, path, cfg, depth, limits)
+	case kindTar:
+		return scanTar(f, path, cfg, depth, limits)
+	case kindAr:
+		return scanAr(f, path, cfg, depth, limits)
+	default:
+		return nil, nil
+	}
+}
+
+// scanZip walks a ZIP's central directory (zip needs io.ReaderAt since the
+// format is only parseable from its trailing directory, not sequentially)
+// and streams each entry's contents directly into the hashers, returning
+// whatever ScanResults it collected even if a later entry trips a limit.
+func scanZip(r io.ReaderAt, size int64, label string, cfg ScanConfig, depth int, limits ArchiveLimits) ([]ScanResult, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	if len(zr.File) > limits.MaxEntries {
+		return nil, fmt.Errorf("%w: %s: %d entries exceeds limit of %d", ErrUnsafeArchive, label, len(zr.File), limits.MaxEntries)
+	}
+
+	var results []ScanResult
+	var totalUncompressed, totalCompressed int64
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		entrySize := int64(f.UncompressedSize64)
+		if entrySize > limits.MaxEntryUncompressedSize {
+			return results, fmt.Errorf("%w: %s!%s: entry size %d exceeds per-entry limit of %d", ErrUnsafeArchive, label, f.Name, entrySize, limits.MaxEntryUncompressedSize)
+		}
+		totalUncompressed += entrySize
+		totalCompressed += int64(f.CompressedSize64)
+		if totalUncompressed > limits.MaxTotalUncompressedSize {
+			return results, fmt.Errorf("%w: %s: total uncompressed size exceeds limit of %d", ErrUnsafeArchive, label, limits.MaxTotalUncompressedSize)
+		}
+		if totalCompressed > 0 {
+			if ratio := float64(totalUncompressed) / float64(totalCompressed); ratio > limits.MaxCompressionRatio {
+				return results, fmt.Errorf("%w: %s!%s: compression ratio %.1fx exceeds limit of %.1fx (possible zip bomb)", ErrUnsafeArchive, label, f.Name, ratio, limits.MaxCompressionRatio)
+			}
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return results, err
+		}
+		results = append(results, scanArchiveEntry(label+"!"+f.Name, io.LimitReader(rc, limits.MaxEntryUncompressedSize+1), cfg, depth, limits)...)
+		rc.Close()
+	}
+	return results, nil
+}
+
+// scanTar streams a tar stream's regular-file entries directly into the
+// hashers; unlike zip, tar is sequential so it needs only an io.Reader.
+func scanTar(r io.Reader, label string, cfg ScanConfig, depth int, limits ArchiveLimits) ([]ScanResult, error) {
+	tr := tar.NewReader(r)
+
+	var results []ScanResult
+	var count int
+	var total int64
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return results, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		count++
+		if count > limits.MaxEntries {
+			return results, fmt.Errorf("%w: %s: more than %d entries", ErrUnsafeArchive, label, limits.MaxEntries)
+		}
+		if hdr.Size > limits.MaxEntryUncompressedSize {
+			return results, fmt.Errorf("%w: %s!%s: entry size %d exceeds per-entry limit of %d", ErrUnsafeArchive, label, hdr.Name, hdr.Size, limits.MaxEntryUncompressedSize)
+		}
+		total += hdr.Size
+		if total > limits.MaxTotalUncompressedSize {
+			return results, fmt.Errorf("%w: %s: total uncompressed size exceeds limit of %d", ErrUnsafeArchive, label, limits.MaxTotalUncompressedSize)
+		}
+
+		results = append(results, scanArchiveEntry(label+"!"+hdr.Name, io.LimitReader(tr, limits.MaxEntryUncompressedSize+1), cfg, depth, limits)...)
+	}
+	return results, nil
+}
+
+// scanGzip decompresses a gzip stream and either treats it as a tar.gz/tgz
+// (if the decompressed stream starts with a tar header) or hashes it as a
+// single plain .gz payload.
+func scanGzip(r io.Reader, label string, cfg ScanConfig, depth int, limits ArchiveLimits) ([]ScanResult, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	br := bufio.NewReaderSize(gz, tarUSTAROff+5)
+	header, _ := br.Peek(tarUSTAROff + 5)
+	if detectArchiveKind(header) == kindTar {
+		return scanTar(br, label, cfg, depth+1, limits)
+	}
+
+	name := gz.Name
+	if name == "" {
+		name = strings.TrimSuffix(label, filepath.Ext(label))
+	}
+	return []ScanResult{scanReader(name, io.LimitReader(br, limits.MaxEntryUncompressedSize+1), cfg)}, nil
+}
+
+// scanAr walks a Debian-style ar archive (the container format used by
+// .deb packages, typically holding control.tar.gz and data.tar.gz), feeding
+// each member back through scanArchiveEntry so the nested tar.gz payloads
+// are unpacked and scanned in turn.
+func scanAr(r io.Reader, label string, cfg ScanConfig, depth int, limits ArchiveLimits) ([]ScanResult, error) {
+	rd := ar.NewReader(r)
+
+	var results []ScanResult
+	var count int
+	for {
+		hdr, err := rd.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return results, err
+		}
+
+		count++
+		if count > limits.MaxEntries {
+			return results, fmt.Errorf("%w: %s: more than %d entries", ErrUnsafeArchive, label, limits.MaxEntries)
+		}
+		if hdr.Size > limits.MaxEntryUncompressedSize {
+			return results, fmt.Errorf("%w: %s!%s: entry size %d exceeds per-entry limit of %d", ErrUnsafeArchive, label, hdr.Name, hdr.Size, limits.MaxEntryUncompressedSize)
+		}
+
+		name := strings.TrimSpace(hdr.Name)
+		results = append(results, scanArchiveEntry(label+"!"+name, io.LimitReader(rd, limits.MaxEntryUncompressedSize+1), cfg, depth, limits)...)
+	}
+	return results, nil
+}
+
+// scanArchiveEntry sniffs an archive member's leading bytes and, if it is
+// itself a recognized container and the depth budget allows it, recurses
+// into it instead of hashing it as opaque bytes. ZIP members need
+// io.ReaderAt, so they are buffered (bounded by the caller's LimitReader) to
+// get one; the other formats recurse directly off the streamed reader. A
+// nested-archive error is folded into the returned results as an errored
+// ScanResult rather than aborting the member's siblings.
+func scanArchiveEntry(label string, r io.Reader, cfg ScanConfig, depth int, limits ArchiveLimits) []ScanResult {
+	if depth+1 > limits.MaxDepth {
+		return []ScanResult{scanReader(label, r, cfg)}
+	}
+
+	br := bufio.NewReaderSize(r, tarUSTAROff+5)
+	header, _ := br.Peek(tarUSTAROff + 5)
+
+	var results []ScanResult
+	var err error
+	switch detectArchiveKind(header) {
+	case kindZip:
+		var data []byte
+		data, err = io.ReadAll(br)
+		if err == nil {
+			results, err = scanZip(bytes.NewReader(data), int64(len(data)), label, cfg, depth+1, limits)
+		}
+	case kindGzip:
+		results, err = scanGzip(br, label, cfg, depth+1, limits)
+	case kindTar:
+		results, err = scanTar(br, label, cfg, depth+1, limits)
+	case kindAr:
+		results, err = scanAr(br, label, cfg, depth+1, limits)
+	default:
+		return []ScanResult{scanReader(label, br, cfg)}
+	}
+	if err != nil {
+		results = append(results, ScanResult{Path: label, Err: fmt.Sprintf("nested archive: %v", err)})
+	}
+	return results
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func buildZip(t *testing.T, entries map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range entries {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("create entry %s: %v", name, err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("write entry %s: %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func testCfg() ScanConfig {
+	return ScanConfig{Hashers: StreamingHashersForAlgos([]Algo{AlgoMD5})}
+}
+
+// A zip entry with a path-traversal-style name must be scanned safely:
+// scanZip never writes anything to disk, so there is nothing for such a
+// name to escape out of, but it should still be hashed like any other
+// entry instead of being rejected or mishandled.
+func TestScanZipPathTraversalEntryIsScannedSafely(t *testing.T) {
+	data := buildZip(t, map[string]string{"../../etc/passwd": "root:x:0:0::/root:/bin/sh"})
+	limits := DefaultArchiveLimits()
+
+	results, err := scanZip(bytes.NewReader(data), int64(len(data)), "evil.zip", testCfg(), 0, limits)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d: %+v", len(results), results)
+	}
+	if !strings.HasSuffix(results[0].Path, "!../../etc/passwd") {
+		t.Fatalf("expected entry name preserved in label, got %q", results[0].Path)
+	}
+	if results[0].Algorithms[AlgoMD5] == "" {
+		t.Fatalf("expected entry to be hashed, got %+v", results[0])
+	}
+}
+
+// A single entry larger than MaxEntryUncompressedSize must abort the
+// archive with ErrUnsafeArchive rather than being hashed.
+func TestScanZipOversizedEntryRejected(t *testing.T) {
+	data := buildZip(t, map[string]string{"big.bin": strings.Repeat("A", 1024)})
+	limits := DefaultArchiveLimits()
+	limits.MaxEntryUncompressedSize = 100
+
+	_, err := scanZip(bytes.NewReader(data), int64(len(data)), "bomb.zip", testCfg(), 0, limits)
+	if err == nil {
+		t.Fatal("expected an error for an oversized entry, got nil")
+	}
+	if !strings.Contains(err.Error(), "unsafe archive entry") {
+		t.Fatalf("expected ErrUnsafeArchive, got: %v", err)
+	}
+}
+
+// A single archive with more entries than MaxEntries must be rejected
+// outright, before any entry is read.
+func TestScanZipTooManyEntriesRejected(t *testing.T) {
+	data := buildZip(t, map[string]string{"a.txt": "a", "b.txt": "b", "c.txt": "c"})
+	limits := DefaultArchiveLimits()
+	limits.MaxEntries = 2
+
+	_, err := scanZip(bytes.NewReader(data), int64(len(data)), "many.zip", testCfg(), 0, limits)
+	if err == nil {
+		t.Fatal("expected an error for too many entries, got nil")
+	}
+	if !strings.Contains(err.Error(), "unsafe archive entry") {
+		t.Fatalf("expected ErrUnsafeArchive, got: %v", err)
+	}
+}
+
+// Once recursion would exceed MaxDepth, scanArchiveEntry must stop
+// unpacking nested archives and hash the member as opaque bytes instead of
+// recursing further.
+func TestScanArchiveEntryStopsRecursingPastMaxDepth(t *testing.T) {
+	inner := buildZip(t, map[string]string{"payload.txt": "payload"})
+	outer := buildZip(t, map[string]string{"inner.zip": string(inner)})
+	limits := DefaultArchiveLimits()
+	limits.MaxDepth = 0 // any nested archive is already past the budget
+
+	results, err := scanZip(bytes.NewReader(outer), int64(len(outer)), "outer.zip", testCfg(), 0, limits)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected the nested zip to be scanned as one opaque entry, got %d results: %+v", len(results), results)
+	}
+	if !strings.HasSuffix(results[0].Path, "!inner.zip") {
+		t.Fatalf("expected inner.zip to be reported as opaque, not unpacked; got %q", results[0].Path)
+	}
+}
@@ -1,188 +1,122 @@
 package main
 
 import (
-	"archive/zip"     // for handling zip file extraction
-	"crypto/md5"       // for computing file hashes
-	"encoding/hex"     // for converting hash bytes to hex strings
-	"flag"             // for command-line argument parsing
-	"fmt"              // for output printing
-	"io"
-	"io/ioutil"
+	"context"
+	"flag"
+	"fmt"
 	"os"
-	"path/filepath"    // for path manipulation
+	"runtime"
 	"strings"
-	"bufio"            // for reading files line by line
+
+	"github.com/sudoxx2/arfarf/feeds"
 )
 
-// Global map to store known malware MD5 hashes
-var malwareHashes = map[string]bool{}
+// hashDir is where both a normal scan's loadHashesFromDir and -update's feed
+// persistence look for local hash-list files, so a feed fetched by -update
+// is picked up by the very next scan.
+const hashDir = "virus_md5_hashes"
 
-// Load all .md5 hash files from a given directory
-func loadHashesFromDir(dir string) error {
-	files, err := ioutil.ReadDir(dir)
-	if err != nil {
-		return err
-	}
+// Entry point: parse CLI args, load hashes, then scan the directory (or, in
+// -update mode, refresh the configured feeds and exit). Exits non-zero if
+// the scan turns up any malware match, so it can be used as a CI gate.
+func main() {
+	dirPtr := flag.String("scan", ".", "Directory to scan")
+	algosPtr := flag.String("algos", "md5,sha1,sha256,xxhash", "Comma-separated digest algorithms to compute (add imohash once a hash list populates malwareHashes[imohash])")
+	updatePtr := flag.Bool("update", false, "Refresh all configured hash feeds and exit")
+	requireSignedPtr := flag.Bool("require-signed-feeds", false, "Refuse to load feeds with no valid PGP signature")
+	keyringPtr := flag.String("feed-keyring", "", "Path to an armored PGP public keyring for verifying feed signatures")
+	feedURLPtr := flag.String("feed-url", "", "URL of an additional plain-text hash-list feed to fetch on -update")
+	feedNamePtr := flag.String("feed-name", "custom", "Name for the -feed-url feed (used for caching and its local hash-file name)")
+	feedAlgoPtr := flag.String("feed-algo", "sha256", "Digest algorithm of the -feed-url feed (md5, sha1, or sha256)")
+	feedSigURLPtr := flag.String("feed-sig-url", "", "URL of a detached PGP signature for -feed-url, if it publishes one")
+	jobsPtr := flag.Int("jobs", runtime.NumCPU(), "Number of files to scan concurrently")
+	formatPtr := flag.String("format", "text", "Report format: text, ndjson, or sarif")
+	flag.Parse()
 
-	for _, f := range files {
-		if strings.HasSuffix(strings.ToLower(f.Name()), ".md5") {
-			fullPath := filepath.Join(dir, f.Name())
-			fmt.Println("🔍 Loading hashes from:", fullPath)
-			err := loadHashesFromFile(fullPath)
-			if err != nil {
-				fmt.Printf("⚠️ Failed to load %s: %v\n", fullPath, err)
+	if *updatePtr {
+		var extra *feeds.GenericHashList
+		if *feedURLPtr != "" {
+			extra = &feeds.GenericHashList{
+				FeedName: *feedNamePtr,
+				URL:      *feedURLPtr,
+				FeedAlgo: feeds.Algo(*feedAlgoPtr),
+				SigFile:  *feedSigURLPtr,
 			}
 		}
+		runUpdate(context.Background(), *requireSignedPtr, *keyringPtr, extra)
+		return
 	}
-	return nil
-}
-
-// Read a single .md5 file and add each valid hash to the global map
-func loadHashesFromFile(filename string) error {
-	file, err := os.Open(filename)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if len(line) == 32 { // MD5 hashes are 32 hex characters
-			malwareHashes[line] = true
+	var algos []Algo
+	useIMOHash := false
+	for _, a := range strings.Split(*algosPtr, ",") {
+		algo := Algo(strings.TrimSpace(a))
+		if algo == AlgoIMOHash {
+			useIMOHash = true
+			continue
 		}
+		algos = append(algos, algo)
 	}
-
-	return scanner.Err()
-}
-
-// Compute the MD5 hash of a file and return it as a hex string
-func computeMD5(filePath string) (string, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return "", err
+	cfg := ScanConfig{
+		Hashers:    StreamingHashersForAlgos(algos),
+		UseIMOHash: useIMOHash,
 	}
-	defer file.Close()
-
-	hash := md5.New()
-	if _, err := io.Copy(hash, file); err != nil {
-		return "", err
+	if len(cfg.Hashers) == 0 && !cfg.UseIMOHash {
+		fmt.Fprintln(os.Stderr, "❌ No valid algorithms selected")
+		os.Exit(1)
 	}
-	return hex.EncodeToString(hash.Sum(nil)), nil
-}
 
-// Scan a single file, compare its hash to known malware hashes
-func scanFile(filePath string) {
-	md5hash, err := computeMD5(filePath)
-	if err != nil {
-		fmt.Printf("[!] Could not hash %s: %v\n", filePath, err)
-		return
-	}
-	if malwareHashes[md5hash] {
-		fmt.Printf("[⚠️] Malware found: %s\n", filePath)
-	} else {
-		fmt.Printf("[OK] Clean: %s\n", filePath)
+	reporter := ReporterFor(*formatPtr)
+	if reporter == nil {
+		fmt.Fprintf(os.Stderr, "❌ Unknown report format: %s\n", *formatPtr)
+		os.Exit(1)
 	}
-}
 
-// Extract all contents of a ZIP file to a temporary directory
-func extractZip(zipPath string) (string, error) {
-	r, err := zip.OpenReader(zipPath)
-	if err != nil {
-		return "", err
-	}
-	defer r.Close()
+	// All of the following is progress/diagnostic chatter, not scan
+	// output, so it goes to stderr: piping -format ndjson/sarif into jq
+	// or a SARIF uploader must see nothing but the reporter's payload on
+	// stdout.
+	fmt.Fprintln(os.Stderr, "🛡️  Malware Scanner (multi-algorithm + ZIP/tar/gzip/deb support)")
+	fmt.Fprintf(os.Stderr, "📂 Scanning: %s\n\n", *dirPtr)
 
-	tempDir, err := ioutil.TempDir("", "unzipped")
+	// Load hash-list files from the hash directory, including anything
+	// -update most recently persisted there from a remote feed.
+	err := loadHashesFromDir(hashDir)
 	if err != nil {
-		return "", err
+		fmt.Fprintf(os.Stderr, "❌ Failed to load hash directory: %v\n", err)
+		os.Exit(1)
 	}
 
-	for _, f := range r.File {
-		fPath := filepath.Join(tempDir, f.Name)
-
-		if f.FileInfo().IsDir() {
-			os.MkdirAll(fPath, os.ModePerm)
-			continue
-		}
-
-		if err := os.MkdirAll(filepath.Dir(fPath), os.ModePerm); err != nil {
-			return "", err
-		}
-
-		dstFile, err := os.OpenFile(fPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
-		if err != nil {
-			return "", err
-		}
-
-		fileInArchive, err := f.Open()
-		if err != nil {
-			return "", err
-		}
-
-		_, err = io.Copy(dstFile, fileInArchive)
-		dstFile.Close()
-		fileInArchive.Close()
-		if err != nil {
-			return "", err
-		}
+	total := 0
+	for _, m := range malwareHashes {
+		total += len(m)
 	}
+	fmt.Fprintf(os.Stderr, "✅ Total hashes loaded: %d\n", total)
 
-	return tempDir, nil
-}
-
-// Recursively walk a directory, scan files, and extract+scan ZIPs
-func scanDirectory(path string) {
-	err := filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
-		if err != nil {
-			fmt.Printf("[!] Error accessing %s: %v\n", filePath, err)
-			return nil
-		}
-		if info.IsDir() {
-			return nil
-		}
+	// Scan the selected directory across jobs worker goroutines, then
+	// render the collected results through the chosen reporter.
+	results := scanDirectory(*dirPtr, cfg, *jobsPtr)
 
-		if strings.HasSuffix(strings.ToLower(filePath), ".zip") {
-			fmt.Printf("[📦] ZIP detected: %s → extracting...\n", filePath)
-			unzippedDir, err := extractZip(filePath)
-			if err != nil {
-				fmt.Printf("[!] Error extracting %s: %v\n", filePath, err)
-				return nil
-			}
-			scanDirectory(unzippedDir) // scan extracted files recursively
-			return nil
+	exitCode := 0
+	for _, r := range results {
+		if r.Matched {
+			exitCode = 1
+			break
 		}
-
-		scanFile(filePath)
-		return nil
-	})
-
-	if err != nil {
-		fmt.Printf("[!] Scan error: %v\n", err)
 	}
-}
 
-// Entry point: parse CLI args, load hashes, then scan the directory
-func main() {
-	dirPtr := flag.String("scan", ".", "Directory to scan")
-	flag.Parse()
-
-	fmt.Println("🛡️  Malware Scanner (MD5 + ZIP support)")
-	fmt.Printf("📂 Scanning: %s\n\n", *dirPtr)
-
-	// Load .md5 files from hash directory
-	err := loadHashesFromDir("virus_md5_hashes")
-	if err != nil {
-		fmt.Printf("❌ Failed to load hash directory: %v\n", err)
-		return
+	if err := reporter.Report(os.Stdout, results); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to render report: %v\n", err)
+		exitCode = 1
 	}
 
-	fmt.Printf("✅ Total hashes loaded: %d\n", len(malwareHashes))
-
-	// Begin scanning the selected directory
-	scanDirectory(*dirPtr)
+	// Wait for Enter before exiting (useful when run by double-click); only
+	// for the interactive text format, so a CI invocation asking for
+	// -format ndjson/sarif never blocks on stdin.
+	if *formatPtr == "text" || *formatPtr == "" {
+		fmt.Println("\nPress Enter to exit...")
+		fmt.Scanln()
+	}
 
-	// Wait for Enter before exiting (useful when run by double-click)
-	fmt.Println("\nPress Enter to exit...")
-	fmt.Scanln()
+	os.Exit(exitCode)
 }
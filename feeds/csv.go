@@ -0,0 +1,108 @@
+package feeds
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// extractCSVColumn reads a CSV feed export and pulls out every value in the
+// column named column that is exactly digestLen hex characters long, one
+// per output line. Looking the column up by name rather than a fixed index
+// tolerates the column reordering abuse.ch has done across export
+// versions.
+//
+// abuse.ch comments out the header row itself (e.g.
+// "# first_seen_utc,sha256_hash,md5_hash,..."), not just the descriptive
+// preamble above it, so a leading run of '#' lines can't simply be
+// discarded: each is tried as a candidate header, and whichever one
+// contains column is used. If none of them do (a plain export with an
+// uncommented header, or no header at all), the first real line is tried
+// as the header instead, as before.
+func extractCSVColumn(r io.Reader, column string, digestLen int) (string, error) {
+	br := bufio.NewReader(r)
+
+	var headerCandidates []string
+	for {
+		peek, err := br.Peek(1)
+		if err != nil {
+			if err == io.EOF {
+				return "", nil
+			}
+			return "", err
+		}
+		if peek[0] != '#' {
+			break
+		}
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		headerCandidates = append(headerCandidates, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#")))
+		if err == io.EOF {
+			break
+		}
+	}
+
+	cr := csv.NewReader(br)
+	cr.FieldsPerRecord = -1
+
+	col := -1
+	for _, candidate := range headerCandidates {
+		fields, err := csv.NewReader(strings.NewReader(candidate)).Read()
+		if err != nil {
+			continue
+		}
+		if idx := columnIndex(fields, column); idx != -1 {
+			col = idx
+			break
+		}
+	}
+
+	if col == -1 {
+		header, err := cr.Read()
+		if err == io.EOF {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		col = columnIndex(header, column)
+		if col == -1 {
+			return "", nil
+		}
+	}
+
+	var buf bytes.Buffer
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if col >= len(record) {
+			continue
+		}
+		digest := strings.ToLower(strings.TrimSpace(record[col]))
+		if len(digest) == digestLen {
+			buf.WriteString(digest)
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.String(), nil
+}
+
+// columnIndex returns the index of the field in fields matching column
+// case-insensitively, or -1 if none matches.
+func columnIndex(fields []string, column string) int {
+	for i, name := range fields {
+		if strings.EqualFold(strings.TrimSpace(name), column) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,82 @@
+package feeds
+
+import (
+	"strings"
+	"testing"
+)
+
+// Real abuse.ch exports comment out the header row itself, not just the
+// descriptive preamble above it, e.g.:
+//
+//	# abuse.ch MalwareBazaar Malware Hash Database
+//	# Last updated: ...
+//	#
+//	# first_seen_utc,sha256_hash,md5_hash,sha1_hash,reporter
+//	"2024-01-01 00:00:00","aaaa...","bbbb...","cccc...","someone"
+func TestExtractCSVColumnCommentedHeader(t *testing.T) {
+	csv := "# abuse.ch MalwareBazaar Malware Hash Database\n" +
+		"# Last updated: 2024-01-01 00:00:00 UTC\n" +
+		"#\n" +
+		"# first_seen_utc,sha256_hash,md5_hash,sha1_hash,reporter\n" +
+		"\"2024-01-01 00:00:00\",\"" + strings.Repeat("a", 64) + "\",\"" + strings.Repeat("b", 32) + "\",\"" + strings.Repeat("c", 40) + "\",\"someone\"\n" +
+		"\"2024-01-02 00:00:00\",\"" + strings.Repeat("d", 64) + "\",\"" + strings.Repeat("e", 32) + "\",\"" + strings.Repeat("f", 40) + "\",\"someone\"\n"
+
+	got, err := extractCSVColumn(strings.NewReader(csv), "sha256_hash", 64)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := strings.Repeat("a", 64) + "\n" + strings.Repeat("d", 64) + "\n"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+// A plain export with an uncommented header must still work exactly as
+// before: no leading '#' lines means the first real line is the header.
+func TestExtractCSVColumnUncommentedHeader(t *testing.T) {
+	csv := "sha256_hash,md5_hash\n" +
+		strings.Repeat("a", 64) + "," + strings.Repeat("b", 32) + "\n"
+
+	got, err := extractCSVColumn(strings.NewReader(csv), "sha256_hash", 64)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := strings.Repeat("a", 64) + "\n"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+// Descriptive '#' preamble that never actually contains the target column
+// (no commented header at all) must fall through to the first real line,
+// rather than being mistaken for a header match.
+func TestExtractCSVColumnCommentPreambleNoHeaderMatch(t *testing.T) {
+	csv := "# generated 2024-01-01\n" +
+		"# contact: abuse@example.com\n" +
+		"sha256_hash\n" +
+		strings.Repeat("a", 64) + "\n"
+
+	got, err := extractCSVColumn(strings.NewReader(csv), "sha256_hash", 64)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := strings.Repeat("a", 64) + "\n"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+// No matching column anywhere (commented or not) must return no digests and
+// no error, same as an empty feed.
+func TestExtractCSVColumnNoMatchingColumn(t *testing.T) {
+	csv := "# first_seen_utc,md5_hash\n" +
+		"\"2024-01-01\",\"" + strings.Repeat("b", 32) + "\"\n"
+
+	got, err := extractCSVColumn(strings.NewReader(csv), "sha256_hash", 64)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "" {
+		t.Fatalf("expected no digests, got %q", got)
+	}
+}
@@ -0,0 +1,37 @@
+// Package feeds fetches, caches and optionally PGP-verifies malware hash
+// lists from remote sources, normalizing each into one hex digest per line
+// so the caller can load them the same way it loads a local hash file.
+package feeds
+
+import (
+	"context"
+	"io"
+)
+
+// Algo identifies the digest algorithm a feed's hashes are expressed in.
+// It mirrors the main package's Algo type as a plain string so this package
+// stays independent of package main.
+type Algo string
+
+const (
+	AlgoMD5    Algo = "md5"
+	AlgoSHA1   Algo = "sha1"
+	AlgoSHA256 Algo = "sha256"
+)
+
+// Feed is a remote source of known-malware digests.
+type Feed interface {
+	// Name identifies the feed for logging and cache-key purposes.
+	Name() string
+	// Algo reports which algorithm the feed's digests are expressed in.
+	Algo() Algo
+	// Fetch downloads (or returns from cache) the feed and returns its raw
+	// content, normalized to one hex digest per line.
+	Fetch(ctx context.Context) (io.ReadCloser, error)
+	// SigURL returns the URL of a detached PGP signature for this feed's
+	// data, or "" if the feed does not ship one.
+	SigURL() string
+	// Verify checks sig as a detached PGP signature over data. Feeds with
+	// no signature (SigURL() == "") should return nil unconditionally.
+	Verify(data, sig []byte) error
+}
@@ -0,0 +1,45 @@
+package feeds
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+)
+
+// MalwareBazaarURL is abuse.ch's full SHA-256 hash dump in CSV form.
+const MalwareBazaarURL = "https://bazaar.abuse.ch/export/csv/full/"
+
+// MalwareBazaarCSV fetches the MalwareBazaar full CSV export and normalizes
+// its sha256_hash column into one digest per line. MalwareBazaar does not
+// publish a detached signature for this export, so SigURL is always "".
+type MalwareBazaarCSV struct {
+	URL string // override for testing; defaults to MalwareBazaarURL
+}
+
+func (m *MalwareBazaarCSV) Name() string                { return "malwarebazaar" }
+func (m *MalwareBazaarCSV) Algo() Algo                  { return AlgoSHA256 }
+func (m *MalwareBazaarCSV) SigURL() string              { return "" }
+func (m *MalwareBazaarCSV) Verify([]byte, []byte) error { return nil }
+
+func (m *MalwareBazaarCSV) Fetch(ctx context.Context) (io.ReadCloser, error) {
+	url := m.URL
+	if url == "" {
+		url = MalwareBazaarURL
+	}
+	path, err := DownloadOrCache(ctx, cacheKey(m.Name()), "", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	digests, err := extractCSVColumn(f, "sha256_hash", 64)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(strings.NewReader(digests)), nil
+}
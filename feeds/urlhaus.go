@@ -0,0 +1,46 @@
+package feeds
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+)
+
+// URLhausPayloadsURL is abuse.ch's URLhaus payload feed, a CSV of malware
+// samples seen distributed from tracked URLs.
+const URLhausPayloadsURL = "https://urlhaus-api.abuse.ch/v1/payloads/recent/"
+
+// URLhausCSV fetches the URLhaus recent-payloads CSV export and normalizes
+// its md5_hash column into one digest per line. Like MalwareBazaar, URLhaus
+// does not publish a detached signature for this export.
+type URLhausCSV struct {
+	URL string // override for testing; defaults to URLhausPayloadsURL
+}
+
+func (u *URLhausCSV) Name() string                { return "urlhaus" }
+func (u *URLhausCSV) Algo() Algo                  { return AlgoMD5 }
+func (u *URLhausCSV) SigURL() string              { return "" }
+func (u *URLhausCSV) Verify([]byte, []byte) error { return nil }
+
+func (u *URLhausCSV) Fetch(ctx context.Context) (io.ReadCloser, error) {
+	url := u.URL
+	if url == "" {
+		url = URLhausPayloadsURL
+	}
+	path, err := DownloadOrCache(ctx, cacheKey(u.Name()), "", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	digests, err := extractCSVColumn(f, "md5_hash", 32)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(strings.NewReader(digests)), nil
+}
@@ -0,0 +1,27 @@
+package feeds
+
+import (
+	"bytes"
+	"os"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// LoadKeyring reads an armored PGP public keyring from path. Callers pass
+// the result to feeds whose Verify method checks a detached signature
+// against it.
+func LoadKeyring(path string) (openpgp.EntityList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return openpgp.ReadArmoredKeyRing(f)
+}
+
+// verifyDetached checks sig as a detached PGP signature over data against
+// keyring. Shared by feed implementations that ship a SigURL.
+func verifyDetached(keyring openpgp.EntityList, data, sig []byte) error {
+	_, err := openpgp.CheckDetachedSignature(keyring, bytes.NewReader(data), bytes.NewReader(sig))
+	return err
+}
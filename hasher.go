@@ -0,0 +1,97 @@
+package main
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/kalafut/imohash"
+)
+
+// Algo identifies a supported digest algorithm.
+type Algo string
+
+const (
+	AlgoMD5     Algo = "md5"
+	AlgoSHA1    Algo = "sha1"
+	AlgoSHA256  Algo = "sha256"
+	AlgoXXHash  Algo = "xxhash"
+	AlgoIMOHash Algo = "imohash"
+)
+
+// Hasher produces a streaming hash.Hash for a single algorithm, so multiple
+// Hashers can be fed the same file in one io.MultiWriter pass.
+type Hasher interface {
+	Algo() Algo
+	New() hash.Hash
+}
+
+type md5Hasher struct{}
+
+func (md5Hasher) Algo() Algo     { return AlgoMD5 }
+func (md5Hasher) New() hash.Hash { return md5.New() }
+
+type sha1Hasher struct{}
+
+func (sha1Hasher) Algo() Algo     { return AlgoSHA1 }
+func (sha1Hasher) New() hash.Hash { return sha1.New() }
+
+type sha256Hasher struct{}
+
+func (sha256Hasher) Algo() Algo     { return AlgoSHA256 }
+func (sha256Hasher) New() hash.Hash { return sha256.New() }
+
+type xxHasher struct{}
+
+func (xxHasher) Algo() Algo     { return AlgoXXHash }
+func (xxHasher) New() hash.Hash { return xxhash.New() }
+
+// streamingHashers returns every algorithm that can be computed in a single
+// sequential io.MultiWriter pass over the file.
+func streamingHashers() []Hasher {
+	return []Hasher{
+		md5Hasher{},
+		sha1Hasher{},
+		sha256Hasher{},
+		xxHasher{},
+	}
+}
+
+// StreamingHashersForAlgos filters streamingHashers down to the requested
+// algorithms. An unknown or non-streaming algo name (e.g. "imohash") is
+// silently skipped; callers that care should validate up front.
+func StreamingHashersForAlgos(algos []Algo) []Hasher {
+	want := make(map[Algo]bool, len(algos))
+	for _, a := range algos {
+		want[a] = true
+	}
+	var out []Hasher
+	for _, h := range streamingHashers() {
+		if want[h.Algo()] {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// computeIMOHash fingerprints filePath with kalafut/imohash, which only
+// samples the head, middle and tail of large files via random-access reads
+// instead of a sequential full-file read. That makes it a poor fit for the
+// shared io.MultiWriter pass the streaming hashers use, so it's checked as
+// its own opt-in algorithm (AlgoIMOHash) rather than folded into that pass.
+//
+// It is not in the default -algos set: no loader populates
+// malwareHashes[AlgoIMOHash] yet (loadHashesFromDir only recognizes
+// .md5/.sha1/.sha256 files, and no feed emits imohash digests), so until
+// one exists, scanFile's imohash check can never match anything and would
+// just cost every scanned file an extra full read for nothing.
+func computeIMOHash(filePath string) (string, error) {
+	sum, err := imohash.SumFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Entry describes one known-bad digest loaded from a hash feed.
+type Entry struct {
+	Name   string // identifier from the source list, e.g. "eicar_test_file"
+	Source string // where it was loaded from, e.g. a file path or feed name
+}
+
+// malwareHashes holds known malware digests, keyed first by algorithm so
+// e.g. a SHA-256 feed never collides with an MD5 one.
+var malwareHashes = map[Algo]map[string]Entry{
+	AlgoMD5:     {},
+	AlgoSHA1:    {},
+	AlgoSHA256:  {},
+	AlgoXXHash:  {},
+	AlgoIMOHash: {},
+}
+
+// extAlgos maps the file extensions under virus_md5_hashes/ to the algorithm
+// whose digests they contain.
+var extAlgos = map[string]Algo{
+	".md5":    AlgoMD5,
+	".sha1":   AlgoSHA1,
+	".sha256": AlgoSHA256,
+}
+
+// expectedLen is the hex-digest length for each algorithm we load from disk.
+var expectedLen = map[Algo]int{
+	AlgoMD5:    32,
+	AlgoSHA1:   40,
+	AlgoSHA256: 64,
+}
+
+// algoFileExt is extAlgos inverted: the hash-list file extension
+// loadHashesFromDir recognizes for each algorithm. Used to persist a
+// fetched feed's digests as a file the next scan's loadHashesFromDir call
+// will pick up on its own.
+var algoFileExt = map[Algo]string{
+	AlgoMD5:    ".md5",
+	AlgoSHA1:   ".sha1",
+	AlgoSHA256: ".sha256",
+}
+
+// loadHashesFromDir loads every .md5, .sha1 and .sha256 file found directly
+// under dir, adding their contents to malwareHashes.
+func loadHashesFromDir(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		ext := strings.ToLower(filepath.Ext(f.Name()))
+		algo, ok := extAlgos[ext]
+		if !ok {
+			continue
+		}
+		fullPath := filepath.Join(dir, f.Name())
+		fmt.Fprintln(os.Stderr, "🔍 Loading hashes from:", fullPath)
+		if err := loadHashesFromFile(fullPath, algo, fullPath); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️ Failed to load %s: %v\n", fullPath, err)
+		}
+	}
+	return nil
+}
+
+// loadHashesFromFile reads a single hash-list file and adds each valid
+// digest to malwareHashes under algo. Lines may be a bare digest, or a
+// "digest  name" pair as produced by tools like md5sum/sha256sum. source
+// identifies where the digest came from, for Entry.Source.
+func loadHashesFromFile(filename string, algo Algo, source string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return loadHashesFromReader(file, algo, source)
+}
+
+// loadHashesFromReader reads hash-list content from r and adds each valid
+// digest to malwareHashes under algo, tagging each Entry with source.
+// Shared by loadHashesFromFile and the feeds subsystem, which hands back
+// already-downloaded feed content.
+func loadHashesFromReader(r io.Reader, algo Algo, source string) error {
+	want := expectedLen[algo]
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		digest := strings.ToLower(fields[0])
+		if len(digest) != want {
+			continue
+		}
+		name := digest
+		if len(fields) > 1 {
+			name = strings.TrimPrefix(fields[1], "*")
+		}
+		malwareHashes[algo][digest] = Entry{Name: name, Source: source}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ScanResult is the outcome of scanning a single file or archive member. It
+// is the unit structured reporters emit; plain text reporting is just one
+// more renderer of the same data.
+type ScanResult struct {
+	Path        string          `json:"path"`
+	Size        int64           `json:"size"`
+	Algorithms  map[Algo]string `json:"algorithms,omitempty"`
+	Matched     bool            `json:"matched"`
+	MatchedAlgo Algo            `json:"matched_algo,omitempty"`
+	MatchedName string          `json:"matched_name,omitempty"`
+	Source      string          `json:"source,omitempty"`
+	Elapsed     time.Duration   `json:"elapsed_ns"`
+	Err         string          `json:"error,omitempty"`
+}
+
+// Reporter renders a completed scan's results to w.
+type Reporter interface {
+	Report(w io.Writer, results []ScanResult) error
+}
+
+// ReporterFor resolves a -format flag value to its Reporter, or nil if the
+// name isn't recognized.
+func ReporterFor(format string) Reporter {
+	switch format {
+	case "text", "":
+		return TextReporter{}
+	case "ndjson":
+		return NDJSONReporter{}
+	case "sarif":
+		return SARIFReporter{}
+	default:
+		return nil
+	}
+}
+
+// TextReporter reproduces the scanner's original human-readable, one-line-
+// per-result output.
+type TextReporter struct{}
+
+func (TextReporter) Report(w io.Writer, results []ScanResult) error {
+	for _, r := range results {
+		switch {
+		case r.Err != "":
+			fmt.Fprintf(w, "[!] Could not scan %s: %s\n", r.Path, r.Err)
+		case r.Matched:
+			fmt.Fprintf(w, "[⚠️] Malware found: %s (%s:%s, %s)\n", r.Path, r.MatchedAlgo, r.Algorithms[r.MatchedAlgo], r.MatchedName)
+		default:
+			fmt.Fprintf(w, "[OK] Clean: %s\n", r.Path)
+		}
+	}
+	return nil
+}
+
+// NDJSONReporter emits one JSON object per result, newline-delimited, so a
+// scan can be piped into jq or any other line-oriented JSON tool.
+type NDJSONReporter struct{}
+
+func (NDJSONReporter) Report(w io.Writer, results []ScanResult) error {
+	enc := json.NewEncoder(w)
+	for _, r := range results {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SARIFReporter renders matches as a SARIF 2.1.0 log so results can be
+// uploaded straight to GitHub code scanning. Clean results carry no
+// actionable location and are omitted, matching how SARIF consumers expect
+// a run's "results" array to only list findings.
+type SARIFReporter struct{}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string        `json:"id"`
+	Name             string        `json:"name"`
+	ShortDescription sarifMultiMsg `json:"shortDescription"`
+}
+
+type sarifMultiMsg struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMultiMsg   `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+const sarifMalwareRuleID = "malware-match"
+
+func (SARIFReporter) Report(w io.Writer, results []ScanResult) error {
+	log := sarifLog{
+		Schema:  "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{
+			{
+				Tool: sarifTool{
+					Driver: sarifDriver{
+						Name:           "arfarf",
+						InformationURI: "https://github.com/sudoxx2/arfarf",
+						Rules: []sarifRule{
+							{
+								ID:               sarifMalwareRuleID,
+								Name:             "MalwareSignatureMatch",
+								ShortDescription: sarifMultiMsg{Text: "File digest matches a known-malware hash list entry."},
+							},
+						},
+					},
+				},
+				Results: []sarifResult{},
+			},
+		},
+	}
+
+	for _, r := range results {
+		if !r.Matched {
+			continue
+		}
+		msg := fmt.Sprintf("%s matched known-malware signature %q via %s:%s", r.Path, r.MatchedName, r.MatchedAlgo, r.Algorithms[r.MatchedAlgo])
+		log.Runs[0].Results = append(log.Runs[0].Results, sarifResult{
+			RuleID:  sarifMalwareRuleID,
+			Level:   "error",
+			Message: sarifMultiMsg{Text: msg},
+			Locations: []sarifLocation{
+				{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: r.Path}}},
+			},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
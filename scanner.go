@@ -1,133 +1,225 @@
 package main
 
 import (
-	"archive/zip"
-	"crypto/md5"
 	"encoding/hex"
-	"flag"
 	"fmt"
+	"hash"
 	"io"
-	"io/ioutil"
 	"os"
 	"path/filepath"
-	"strings"
+	"runtime"
+	"sync"
+	"time"
 )
 
-// === KNOWN MALWARE HASHES ===
-var malwareHashes = map[string]string{
-	"eicar_test_file": "44d88612fea8a8f36de82e1278abb02f",
+// ScanConfig selects which digests scanFile computes for each candidate.
+type ScanConfig struct {
+	Hashers    []Hasher // streaming algorithms, fed through one io.MultiWriter pass
+	UseIMOHash bool     // also check the fast imohash fingerprint
 }
 
-func computeMD5(filePath string) (string, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return "", err
+// countingReader wraps r and tracks how many bytes have been read through
+// it, so streamed archive members can report a size without needing
+// random access to find one up front.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// hashStream feeds r through every hasher in a single io.MultiWriter pass
+// and returns the resulting hex digests keyed by algorithm.
+func hashStream(r io.Reader, hashers []Hasher) (map[Algo]string, error) {
+	if len(hashers) == 0 {
+		return nil, nil
 	}
-	defer file.Close()
 
-	hash := md5.New()
-	if _, err := io.Copy(hash, file); err != nil {
-		return "", err
+	writers := make([]io.Writer, len(hashers))
+	sums := make([]hash.Hash, len(hashers))
+	for i, h := range hashers {
+		sums[i] = h.New()
+		writers[i] = sums[i]
 	}
-	return hex.EncodeToString(hash.Sum(nil)), nil
-}
 
-func scanFile(filePath string) {
-	md5hash, err := computeMD5(filePath)
-	if err != nil {
-		fmt.Printf("[!] Could not hash %s: %v\n", filePath, err)
-		return
+	if _, err := io.Copy(io.MultiWriter(writers...), r); err != nil {
+		return nil, err
+	}
+
+	digests := make(map[Algo]string, len(hashers))
+	for i, h := range hashers {
+		digests[h.Algo()] = hex.EncodeToString(sums[i].Sum(nil))
 	}
-	for name, knownHash := range malwareHashes {
-		if md5hash == knownHash {
-			fmt.Printf("[⚠️] Malware found: %s (%s)\n", filePath, name)
+	return digests, nil
+}
+
+// matchHashers checks digests, in hasher order, against malwareHashes and
+// records the outcome on result. Order is taken from hashers rather than
+// the map so repeated scans report a deterministic match when a digest
+// happens to appear under more than one algorithm's list.
+func matchHashers(result *ScanResult, hashers []Hasher, digests map[Algo]string) {
+	for _, h := range hashers {
+		digest := digests[h.Algo()]
+		if entry, ok := malwareHashes[h.Algo()][digest]; ok {
+			result.Matched = true
+			result.MatchedAlgo = h.Algo()
+			result.MatchedName = entry.Name
+			result.Source = entry.Source
 			return
 		}
 	}
-	fmt.Printf("[OK] Clean: %s\n", filePath)
 }
 
-func extractZip(zipPath string) (string, error) {
-	r, err := zip.OpenReader(zipPath)
-	if err != nil {
-		return "", err
+// scanFile hashes filePath per cfg, optionally also checking its imohash
+// fingerprint, and returns the outcome as a ScanResult for a Reporter to
+// render.
+func scanFile(filePath string, cfg ScanConfig) ScanResult {
+	result := ScanResult{Path: filePath}
+	if info, err := os.Stat(filePath); err == nil {
+		result.Size = info.Size()
 	}
-	defer r.Close()
 
-	tempDir, err := ioutil.TempDir("", "unzipped")
-	if err != nil {
-		return "", err
+	start := time.Now()
+	defer func() { result.Elapsed = time.Since(start) }()
+
+	if cfg.UseIMOHash {
+		digest, err := computeIMOHash(filePath)
+		if err != nil {
+			result.Err = err.Error()
+			return result
+		}
+		if entry, ok := malwareHashes[AlgoIMOHash][digest]; ok {
+			result.Matched = true
+			result.MatchedAlgo = AlgoIMOHash
+			result.MatchedName = entry.Name
+			result.Source = entry.Source
+			result.Algorithms = map[Algo]string{AlgoIMOHash: digest}
+			return result
+		}
 	}
 
-	for _, f := range r.File {
-		fPath := filepath.Join(tempDir, f.Name)
+	if len(cfg.Hashers) == 0 {
+		return result
+	}
 
-		if f.FileInfo().IsDir() {
-			os.MkdirAll(fPath, os.ModePerm)
-			continue
-		}
+	file, err := os.Open(filePath)
+	if err != nil {
+		result.Err = err.Error()
+		return result
+	}
+	defer file.Close()
 
-		if err := os.MkdirAll(filepath.Dir(fPath), os.ModePerm); err != nil {
-			return "", err
-		}
+	digests, err := hashStream(file, cfg.Hashers)
+	if err != nil {
+		result.Err = err.Error()
+		return result
+	}
+	result.Algorithms = digests
+	matchHashers(&result, cfg.Hashers, digests)
+	return result
+}
 
-		dstFile, err := os.OpenFile(fPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
-		if err != nil {
-			return "", err
-		}
+// scanReader hashes an archive member (identified by label, e.g.
+// "bundle.tar.gz!payload.exe") per cfg.Hashers and returns the outcome.
+// imohash needs random access to the whole file, so it only runs for
+// scanFile and is skipped for streamed archive members.
+func scanReader(label string, r io.Reader, cfg ScanConfig) ScanResult {
+	result := ScanResult{Path: label}
+	start := time.Now()
+	cr := &countingReader{r: r}
+
+	digests, err := hashStream(cr, cfg.Hashers)
+	result.Elapsed = time.Since(start)
+	result.Size = cr.n
+	if err != nil {
+		result.Err = err.Error()
+		return result
+	}
+	result.Algorithms = digests
+	matchHashers(&result, cfg.Hashers, digests)
+	return result
+}
 
-		fileInArchive, err := f.Open()
-		if err != nil {
-			return "", err
-		}
+// scanPath classifies a single filesystem path (detected by magic bytes,
+// not just extension) and sends every ScanResult it produces to out: one
+// result for a plain file, or one per archive member for a recognized
+// container, streamed without extracting to disk.
+func scanPath(filePath string, cfg ScanConfig, out chan<- ScanResult) {
+	kind, err := detectArchiveKindFromFile(filePath)
+	if err != nil {
+		out <- ScanResult{Path: filePath, Err: err.Error()}
+		return
+	}
 
-		_, err = io.Copy(dstFile, fileInArchive)
-		dstFile.Close()
-		fileInArchive.Close()
-		if err != nil {
-			return "", err
-		}
+	if kind == kindNone {
+		out <- scanFile(filePath, cfg)
+		return
 	}
 
-	return tempDir, nil
+	fmt.Fprintf(os.Stderr, "[📦] %s detected: %s → scanning in place...\n", kind, filePath)
+	results, err := scanArchive(filePath, kind, cfg, 0)
+	for _, r := range results {
+		out <- r
+	}
+	if err != nil {
+		out <- ScanResult{Path: filePath, Err: fmt.Sprintf("archive scan: %v", err)}
+	}
 }
 
-func scanDirectory(path string) {
-	err := filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
-		if err != nil {
-			fmt.Printf("[!] Error accessing %s: %v\n", filePath, err)
-			return nil
-		}
-		if info.IsDir() {
-			return nil
-		}
+// scanDirectory walks path and scans every file it finds across jobs
+// worker goroutines (runtime.NumCPU() if jobs <= 0). A found archive is
+// unpacked and its members scanned within the same worker, so a directory
+// full of archives never fans out beyond the jobs bound. Results are
+// collected in the order workers finish, not walk order.
+func scanDirectory(path string, cfg ScanConfig, jobs int) []ScanResult {
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+
+	paths := make(chan string, jobs)
+	results := make(chan ScanResult, jobs)
+
+	var workers sync.WaitGroup
+	workers.Add(jobs)
+	for i := 0; i < jobs; i++ {
+		go func() {
+			defer workers.Done()
+			for p := range paths {
+				scanPath(p, cfg, results)
+			}
+		}()
+	}
 
-		if strings.HasSuffix(strings.ToLower(filePath), ".zip") {
-			fmt.Printf("[📦] ZIP detected: %s → extracting...\n", filePath)
-			unzippedDir, err := extractZip(filePath)
+	go func() {
+		err := filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
 			if err != nil {
-				fmt.Printf("[!] Error extracting %s: %v\n", filePath, err)
+				results <- ScanResult{Path: filePath, Err: err.Error()}
 				return nil
 			}
-			scanDirectory(unzippedDir)
+			if info.IsDir() {
+				return nil
+			}
+			paths <- filePath
 			return nil
+		})
+		if err != nil {
+			results <- ScanResult{Path: path, Err: err.Error()}
 		}
+		close(paths)
+	}()
 
-		scanFile(filePath)
-		return nil
-	})
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
 
-	if err != nil {
-		fmt.Printf("[!] Scan error: %v\n", err)
+	var collected []ScanResult
+	for r := range results {
+		collected = append(collected, r)
 	}
-}
-
-func main() {
-	dirPtr := flag.String("scan", ".", "Directory to scan")
-	flag.Parse()
-
-	fmt.Println("🛡️  Malware Scanner (MD5 + ZIP support)")
-	fmt.Printf("📂 Scanning: %s\n\n", *dirPtr)
-
-	scanDirectory(*dirPtr)
+	return collected
 }
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/sudoxx2/arfarf/feeds"
+)
+
+// configuredFeeds lists the remote feeds -update refreshes. Hashes land in
+// the same malwareHashes map local .md5/.sha1/.sha256 files populate.
+var configuredFeeds = []feeds.Feed{
+	&feeds.MalwareBazaarCSV{},
+	&feeds.URLhausCSV{},
+}
+
+// runUpdate refreshes every configured feed, plus extra if non-nil (the
+// -feed-url/-feed-sig-url/-feed-keyring flags, which are how a signed
+// feeds.GenericHashList actually gets exercised), and merges their hashes
+// into malwareHashes. When requireSigned is set, feeds with no signature
+// (or whose signature fails verification) are skipped rather than loaded.
+// keyringPath, if non-empty, loads an armored PGP keyring and attaches it to
+// any feed that ships a detached signature. Each feed's normalized digests
+// are also persisted under hashDir so the next plain scan (which never
+// talks to the network) picks them up via loadHashesFromDir.
+func runUpdate(ctx context.Context, requireSigned bool, keyringPath string, extra *feeds.GenericHashList) {
+	activeFeeds := configuredFeeds
+	if extra != nil {
+		activeFeeds = append(append([]feeds.Feed{}, configuredFeeds...), extra)
+	}
+
+	if keyringPath != "" {
+		keyring, err := feeds.LoadKeyring(keyringPath)
+		if err != nil {
+			fmt.Printf("❌ Failed to load feed keyring %s: %v\n", keyringPath, err)
+		} else {
+			for _, f := range activeFeeds {
+				if g, ok := f.(*feeds.GenericHashList); ok {
+					g.Keyring = keyring
+				}
+			}
+		}
+	}
+
+	for _, f := range activeFeeds {
+		fmt.Printf("⬇️  Updating feed %s (%s)...\n", f.Name(), f.Algo())
+
+		rc, err := f.Fetch(ctx)
+		if err != nil {
+			fmt.Printf("⚠️  Failed to fetch %s: %v\n", f.Name(), err)
+			continue
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			fmt.Printf("⚠️  Failed to read %s: %v\n", f.Name(), err)
+			continue
+		}
+
+		if sigURL := f.SigURL(); sigURL != "" {
+			sigPath, err := feeds.DownloadOrCache(ctx, feeds.CacheKey(f.Name())+".sig", "", sigURL, nil)
+			if err != nil {
+				fmt.Printf("⚠️  Failed to fetch signature for %s: %v\n", f.Name(), err)
+				continue
+			}
+			sig, err := os.ReadFile(sigPath)
+			if err != nil {
+				fmt.Printf("⚠️  Failed to read signature for %s: %v\n", f.Name(), err)
+				continue
+			}
+			if err := f.Verify(data, sig); err != nil {
+				fmt.Printf("⚠️  Signature verification failed for %s: %v\n", f.Name(), err)
+				continue
+			}
+			fmt.Printf("✅ Signature verified for %s\n", f.Name())
+		} else if requireSigned {
+			fmt.Printf("❌ Refusing unsigned feed %s (--require-signed-feeds set)\n", f.Name())
+			continue
+		}
+
+		algo := Algo(f.Algo())
+		if err := loadHashesFromReader(bytes.NewReader(data), algo, f.Name()); err != nil {
+			fmt.Printf("⚠️  Failed to load %s: %v\n", f.Name(), err)
+			continue
+		}
+		fmt.Printf("✅ Loaded %s hashes from %s\n", algo, f.Name())
+
+		if err := persistFeedHashes(hashDir, f.Name(), algo, data); err != nil {
+			fmt.Printf("⚠️  Failed to persist %s hashes for future scans: %v\n", f.Name(), err)
+			continue
+		}
+		fmt.Printf("💾 Saved %s hashes to %s for future scans\n", f.Name(), hashDir)
+	}
+}
+
+// persistFeedHashes writes data (one normalized hex digest per line, as
+// Feed.Fetch promises) to dir/name<ext>, so a later plain scan's
+// loadHashesFromDir call loads it without needing network access. Writing
+// goes through a temp file and rename, same as feeds.DownloadOrCache, so a
+// scan never sees a partially written hash file. algo must have a known
+// loadHashesFromDir extension (extAlgos' inverse); algorithms without one,
+// like xxhash, can't be persisted this way and return an error.
+func persistFeedHashes(dir, name string, algo Algo, data []byte) error {
+	ext, ok := algoFileExt[algo]
+	if !ok {
+		return fmt.Errorf("no local hash-file extension for algorithm %s", algo)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, name+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, name+ext))
+}